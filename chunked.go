@@ -0,0 +1,281 @@
+package toolbox
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chunkSidecar tracks the progress of a single in-flight chunked upload.
+type chunkSidecar struct {
+	Size          int64
+	TotalSize     int64
+	MIME          string
+	Original      string
+	Expiry        time.Time
+	DeleteKeyHash string
+}
+
+func (t *Tools) partialDir(uploadDir string) string {
+	return filepath.Join(uploadDir, ".partial")
+}
+
+func (t *Tools) partialPath(uploadDir, id string) string {
+	return filepath.Join(t.partialDir(uploadDir), id)
+}
+
+func (t *Tools) sidecarPath(uploadDir, id string) string {
+	return filepath.Join(t.partialDir(uploadDir), id+".json")
+}
+
+// HandleChunkedUpload implements a tus-style resumable upload: a POST
+// creates an upload ID and returns it via the Location header, PATCH
+// requests append bytes at a given offset using a Content-Range header,
+// and HEAD reports how many bytes have been received so far so a client
+// can resume after a dropped connection.
+func (t *Tools) HandleChunkedUpload(w http.ResponseWriter, r *http.Request, uploadDir string) {
+	switch r.Method {
+	case http.MethodPost:
+		t.startChunkedUpload(w, r, uploadDir)
+	case http.MethodPatch:
+		t.patchChunkedUpload(w, r, uploadDir)
+	case http.MethodHead:
+		t.headChunkedUpload(w, r, uploadDir)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *Tools) startChunkedUpload(w http.ResponseWriter, r *http.Request, uploadDir string) {
+
+	if t.MaxFileSize == 0 {
+		t.MaxFileSize = 1024 * 1024 * 1024
+	}
+
+	totalSize, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || totalSize <= 0 {
+		http.Error(w, "missing or invalid Upload-Length header", http.StatusBadRequest)
+		return
+	}
+
+	if totalSize > int64(t.MaxFileSize) {
+		http.Error(w, (&ErrFileTooLarge{MaxFileSize: t.MaxFileSize}).Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := t.CreateDirIfNotExist(t.partialDir(uploadDir)); err != nil {
+		_ = t.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	id := t.RandomString(20)
+
+	deleteKey := r.Header.Get("X-Delete-Key")
+	if deleteKey == "" {
+		deleteKey = t.RandomString(32)
+	}
+
+	if f, err := os.Create(t.partialPath(uploadDir, id)); err != nil {
+		_ = t.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	} else {
+		f.Close()
+	}
+
+	sidecar := chunkSidecar{
+		TotalSize:     totalSize,
+		Original:      r.Header.Get("X-File-Name"),
+		DeleteKeyHash: hashKey(deleteKey),
+	}
+
+	if err := t.saveSidecar(uploadDir, id, &sidecar); err != nil {
+		_ = t.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", path.Join(r.URL.Path, id))
+	w.Header().Set("X-Delete-Key", deleteKey)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (t *Tools) patchChunkedUpload(w http.ResponseWriter, r *http.Request, uploadDir string) {
+
+	id := path.Base(r.URL.Path)
+
+	sidecar, err := t.loadSidecar(uploadDir, id)
+	if err != nil {
+		http.Error(w, "upload not found", http.StatusNotFound)
+		return
+	}
+
+	start, end, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if total != sidecar.TotalSize {
+		http.Error(w, "Content-Range total does not match upload", http.StatusBadRequest)
+		return
+	}
+
+	if start != sidecar.Size {
+		w.Header().Set("Upload-Offset", strconv.FormatInt(sidecar.Size, 10))
+		http.Error(w, "offset does not match current upload size", http.StatusConflict)
+		return
+	}
+
+	f, err := os.OpenFile(t.partialPath(uploadDir, id), os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		_ = t.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	expected := end - start + 1
+	written, err := io.Copy(f, io.LimitReader(r.Body, expected))
+	if err != nil {
+		_ = f.Truncate(sidecar.Size)
+		_ = t.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if written != expected {
+		// Discard the short write so a legitimate retry of this same
+		// offset appends after sidecar.Size, not after these orphaned
+		// bytes.
+		_ = f.Truncate(sidecar.Size)
+		http.Error(w, "short chunk body", http.StatusBadRequest)
+		return
+	}
+
+	sidecar.Size += written
+
+	if sidecar.MIME == "" {
+		prefix := make([]byte, 512)
+		n, _ := f.ReadAt(prefix, 0)
+		sidecar.MIME, _ = t.DetectMIME(prefix[:n], sidecar.Original)
+	}
+
+	if sidecar.Size >= sidecar.TotalSize {
+		if err := t.finishChunkedUpload(uploadDir, id, sidecar); err != nil {
+			_ = t.ErrorJSON(w, err, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := t.saveSidecar(uploadDir, id, sidecar); err != nil {
+		_ = t.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(sidecar.Size, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (t *Tools) headChunkedUpload(w http.ResponseWriter, r *http.Request, uploadDir string) {
+
+	id := path.Base(r.URL.Path)
+
+	sidecar, err := t.loadSidecar(uploadDir, id)
+	if err != nil {
+		http.Error(w, "upload not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(sidecar.Size, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(sidecar.TotalSize, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// finishChunkedUpload renames the now-complete partial file into uploadDir
+// and records a normal FileMetadata entry for it.
+func (t *Tools) finishChunkedUpload(uploadDir, id string, sidecar *chunkSidecar) error {
+
+	finalName := id + filepath.Ext(sidecar.Original)
+
+	if err := os.Rename(t.partialPath(uploadDir, id), filepath.Join(uploadDir, finalName)); err != nil {
+		return err
+	}
+
+	store := t.metadataStore(uploadDir)
+
+	if err := store.Save(&FileMetadata{
+		Name:          finalName,
+		OriginalName:  sidecar.Original,
+		Size:          sidecar.Size,
+		MIME:          sidecar.MIME,
+		UploadedAt:    time.Now(),
+		Expiry:        sidecar.Expiry,
+		DeleteKeyHash: sidecar.DeleteKeyHash,
+	}); err != nil {
+		return err
+	}
+
+	return os.Remove(t.sidecarPath(uploadDir, id))
+}
+
+func (t *Tools) saveSidecar(uploadDir, id string, sidecar *chunkSidecar) error {
+	out, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.sidecarPath(uploadDir, id), out, 0644)
+}
+
+func (t *Tools) loadSidecar(uploadDir, id string) (*chunkSidecar, error) {
+	data, err := os.ReadFile(t.sidecarPath(uploadDir, id))
+	if err != nil {
+		return nil, err
+	}
+
+	var sidecar chunkSidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return nil, err
+	}
+
+	return &sidecar, nil
+}
+
+// parseContentRange parses a "bytes X-Y/Z" Content-Range header into its
+// start offset, end offset, and total size.
+func parseContentRange(header string) (start, end, total int64, err error) {
+
+	header = strings.TrimPrefix(header, "bytes ")
+
+	rangeAndTotal := strings.SplitN(header, "/", 2)
+	if len(rangeAndTotal) != 2 {
+		return 0, 0, 0, errors.New("malformed Content-Range header")
+	}
+
+	total, err = strconv.ParseInt(rangeAndTotal[1], 10, 64)
+	if err != nil {
+		return 0, 0, 0, errors.New("malformed Content-Range header")
+	}
+
+	startAndEnd := strings.SplitN(rangeAndTotal[0], "-", 2)
+	if len(startAndEnd) != 2 {
+		return 0, 0, 0, errors.New("malformed Content-Range header")
+	}
+
+	start, err = strconv.ParseInt(startAndEnd[0], 10, 64)
+	if err != nil {
+		return 0, 0, 0, errors.New("malformed Content-Range header")
+	}
+
+	end, err = strconv.ParseInt(startAndEnd[1], 10, 64)
+	if err != nil {
+		return 0, 0, 0, errors.New("malformed Content-Range header")
+	}
+
+	return start, end, total, nil
+}
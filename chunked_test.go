@@ -0,0 +1,176 @@
+package toolbox
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+)
+
+func TestTools_HandleChunkedUpload_FullCycle(t *testing.T) {
+
+	uploadDir := "./testdata/upload/"
+
+	var tools Tools
+
+	content := append([]byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}, []byte("rest")...)
+
+	postReq := httptest.NewRequest("POST", "/chunked", nil)
+	postReq.Header.Set("Upload-Length", strconv.Itoa(len(content)))
+	postReq.Header.Set("X-File-Name", "sample.png")
+
+	postRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(postRR, postReq, uploadDir)
+
+	if postRR.Code != http.StatusCreated {
+		t.Fatalf("expected 201 from POST, got %d", postRR.Code)
+	}
+
+	location := postRR.Header().Get("Location")
+	if location == "" {
+		t.Fatal("expected a Location header")
+	}
+
+	firstChunk := content[:4]
+	patchReq := httptest.NewRequest("PATCH", location, bytes.NewReader(firstChunk))
+	patchReq.Header.Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(firstChunk)-1, len(content)))
+	patchRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(patchRR, patchReq, uploadDir)
+
+	if patchRR.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for a partial chunk, got %d: %s", patchRR.Code, patchRR.Body.String())
+	}
+	if offset := patchRR.Header().Get("Upload-Offset"); offset != strconv.Itoa(len(firstChunk)) {
+		t.Errorf("expected Upload-Offset %d, got %s", len(firstChunk), offset)
+	}
+
+	headReq := httptest.NewRequest("HEAD", location, nil)
+	headRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(headRR, headReq, uploadDir)
+
+	if offset := headRR.Header().Get("Upload-Offset"); offset != strconv.Itoa(len(firstChunk)) {
+		t.Errorf("HEAD expected offset %d, got %s", len(firstChunk), offset)
+	}
+
+	badReq := httptest.NewRequest("PATCH", location, bytes.NewReader(content[5:]))
+	badReq.Header.Set("Content-Range", fmt.Sprintf("bytes 5-%d/%d", len(content)-1, len(content)))
+	badRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(badRR, badReq, uploadDir)
+
+	if badRR.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a mismatched offset, got %d", badRR.Code)
+	}
+
+	secondChunk := content[len(firstChunk):]
+	finalReq := httptest.NewRequest("PATCH", location, bytes.NewReader(secondChunk))
+	finalReq.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", len(firstChunk), len(content)-1, len(content)))
+	finalRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(finalRR, finalReq, uploadDir)
+
+	if finalRR.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 on completion, got %d: %s", finalRR.Code, finalRR.Body.String())
+	}
+
+	id := path.Base(location)
+	finalName := id + ".png"
+
+	data, err := os.ReadFile(uploadDir + finalName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("expected reassembled content %v, got %v", content, data)
+	}
+
+	meta, err := NewJSONMetadataStore(uploadDir + ".meta").Get(finalName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.MIME != "image/png" {
+		t.Errorf("expected the completed upload's MIME to be sniffed as image/png, got %q", meta.MIME)
+	}
+}
+
+func TestTools_HandleChunkedUpload_ShortWriteThenRetry(t *testing.T) {
+
+	uploadDir := "./testdata/upload/"
+
+	var tools Tools
+
+	content := []byte("0123456789ABCDEFGHIJ")
+
+	postReq := httptest.NewRequest("POST", "/chunked", nil)
+	postReq.Header.Set("Upload-Length", strconv.Itoa(len(content)))
+	postReq.Header.Set("X-File-Name", "sample.bin")
+
+	postRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(postRR, postReq, uploadDir)
+
+	if postRR.Code != http.StatusCreated {
+		t.Fatalf("expected 201 from POST, got %d", postRR.Code)
+	}
+
+	location := postRR.Header().Get("Location")
+
+	shortReq := httptest.NewRequest("PATCH", location, bytes.NewReader(content[:5]))
+	shortReq.Header.Set("Content-Range", fmt.Sprintf("bytes 0-9/%d", len(content)))
+	shortRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(shortRR, shortReq, uploadDir)
+
+	if shortRR.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a short chunk body, got %d: %s", shortRR.Code, shortRR.Body.String())
+	}
+
+	retryReq := httptest.NewRequest("PATCH", location, bytes.NewReader(content[:10]))
+	retryReq.Header.Set("Content-Range", fmt.Sprintf("bytes 0-9/%d", len(content)))
+	retryRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(retryRR, retryReq, uploadDir)
+
+	if retryRR.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for the retried chunk, got %d: %s", retryRR.Code, retryRR.Body.String())
+	}
+	if offset := retryRR.Header().Get("Upload-Offset"); offset != "10" {
+		t.Fatalf("expected Upload-Offset 10 after the retry, got %s", offset)
+	}
+
+	finalReq := httptest.NewRequest("PATCH", location, bytes.NewReader(content[10:]))
+	finalReq.Header.Set("Content-Range", fmt.Sprintf("bytes 10-%d/%d", len(content)-1, len(content)))
+	finalRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(finalRR, finalReq, uploadDir)
+
+	if finalRR.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 on completion, got %d: %s", finalRR.Code, finalRR.Body.String())
+	}
+
+	id := path.Base(location)
+	data, err := os.ReadFile(uploadDir + id + ".bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("expected the orphaned short-write bytes to be discarded and content to be %q, got %q", content, data)
+	}
+}
+
+func TestTools_HandleChunkedUpload_RejectsOversizedUploadLength(t *testing.T) {
+
+	uploadDir := "./testdata/upload/"
+
+	var tools Tools
+	tools.MaxFileSize = 10
+
+	postReq := httptest.NewRequest("POST", "/chunked", nil)
+	postReq.Header.Set("Upload-Length", "20")
+	postReq.Header.Set("X-File-Name", "sample.bin")
+
+	postRR := httptest.NewRecorder()
+	tools.HandleChunkedUpload(postRR, postReq, uploadDir)
+
+	if postRR.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for Upload-Length exceeding MaxFileSize, got %d: %s", postRR.Code, postRR.Body.String())
+	}
+}
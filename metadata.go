@@ -0,0 +1,118 @@
+package toolbox
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMetadata is the per-file record persisted by a MetadataStore
+// alongside an uploaded file.
+type FileMetadata struct {
+	Name          string
+	OriginalName  string
+	Size          int64
+	MIME          string
+	UploadedAt    time.Time
+	Expiry        time.Time
+	DeleteKeyHash string
+	AccessKeyHash string
+}
+
+// MetadataStore persists and retrieves FileMetadata records. Implementing
+// this against something other than JSON files (a database, say) lets
+// callers swap the uploads subsystem's bookkeeping without touching
+// Tools.UploadFilesWithOptions.
+type MetadataStore interface {
+	Save(meta *FileMetadata) error
+	Get(name string) (*FileMetadata, error)
+	Delete(name string) error
+	All() ([]*FileMetadata, error)
+}
+
+// JSONMetadataStore is a MetadataStore that keeps one JSON file per upload
+// in Dir, named after the uploaded file.
+type JSONMetadataStore struct {
+	Dir string
+}
+
+// NewJSONMetadataStore returns a JSONMetadataStore rooted at dir.
+func NewJSONMetadataStore(dir string) *JSONMetadataStore {
+	return &JSONMetadataStore{Dir: dir}
+}
+
+func (s *JSONMetadataStore) path(name string) string {
+	return filepath.Join(s.Dir, name+".json")
+}
+
+// Save writes meta to its JSON file, creating Dir if necessary.
+func (s *JSONMetadataStore) Save(meta *FileMetadata) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path(meta.Name), out, 0644)
+}
+
+// Get reads back the metadata for name.
+func (s *JSONMetadataStore) Get(name string) (*FileMetadata, error) {
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return nil, err
+	}
+
+	var meta FileMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+
+	return &meta, nil
+}
+
+// Delete removes the metadata file for name. It is not an error if the
+// file is already gone.
+func (s *JSONMetadataStore) Delete(name string) error {
+	err := os.Remove(s.path(name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// All returns every metadata record currently stored in Dir.
+func (s *JSONMetadataStore) All() ([]*FileMetadata, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var all []*FileMetadata
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.Dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var meta FileMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, err
+		}
+
+		all = append(all, &meta)
+	}
+
+	return all, nil
+}
@@ -0,0 +1,91 @@
+package toolbox
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// mimeSignature is a single file-signature ("magic bytes") entry.
+type mimeSignature struct {
+	sig  []byte
+	mime string
+	ext  string
+}
+
+// mimeSignatures is checked in order against the start of a file; the
+// first match wins. It covers the formats DetectMIME is most often asked
+// to tell apart, falling back to http.DetectContentType for anything else.
+var mimeSignatures = []mimeSignature{
+	{sig: []byte{0x89, 0x50, 0x4E, 0x47}, mime: "image/png", ext: ".png"},
+	{sig: []byte{0xFF, 0xD8, 0xFF}, mime: "image/jpeg", ext: ".jpg"},
+	{sig: []byte("GIF87a"), mime: "image/gif", ext: ".gif"},
+	{sig: []byte("GIF89a"), mime: "image/gif", ext: ".gif"},
+	{sig: []byte("%PDF"), mime: "application/pdf", ext: ".pdf"},
+	{sig: []byte{0x50, 0x4B, 0x03, 0x04}, mime: "application/zip", ext: ".zip"},
+}
+
+// extraSignatureExts covers the formats DetectMIME recognises via a
+// structural check rather than a fixed-prefix entry in mimeSignatures
+// (RIFF/WEBP, the MP4 ftyp box, Ogg), so expectedMIMEForExt stays
+// consistent with everything DetectMIME actually sniffs.
+var extraSignatureExts = map[string]string{
+	".webp": "image/webp",
+	".mp4":  "video/mp4",
+	".ogg":  "audio/ogg",
+}
+
+// DetectMIME identifies the MIME type and canonical extension of a file
+// from its first bytes, checking a table of known file signatures before
+// falling back to http.DetectContentType for anything it doesn't
+// recognise. filename is only used to derive an extension in that
+// fallback case.
+func (t *Tools) DetectMIME(header []byte, filename string) (mime, ext string) {
+
+	for _, s := range mimeSignatures {
+		if bytes.HasPrefix(header, s.sig) {
+			return s.mime, s.ext
+		}
+	}
+
+	if len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")) {
+		return "image/webp", ".webp"
+	}
+
+	if len(header) >= 8 && bytes.Equal(header[4:8], []byte("ftyp")) {
+		return "video/mp4", ".mp4"
+	}
+
+	if len(header) >= 4 && bytes.Equal(header[0:4], []byte("OggS")) {
+		return "audio/ogg", ".ogg"
+	}
+
+	return http.DetectContentType(header), filepath.Ext(filename)
+}
+
+// expectedMIMEForExt reports the MIME type DetectMIME would assign a file
+// with a truthful extension, used by StrictMIME to catch mismatched
+// client-supplied extensions.
+func expectedMIMEForExt(ext string) (string, bool) {
+	for _, s := range mimeSignatures {
+		if strings.EqualFold(s.ext, ext) {
+			return s.mime, true
+		}
+	}
+
+	for extraExt, mime := range extraSignatureExts {
+		if strings.EqualFold(extraExt, ext) {
+			return mime, true
+		}
+	}
+
+	return "", false
+}
+
+// errMIMEMismatch reports a client-supplied extension that contradicts the
+// sniffed content of the file it is attached to.
+func errMIMEMismatch(filename, sniffed string) error {
+	return fmt.Errorf("%q does not look like its sniffed type %s", filename, sniffed)
+}
@@ -0,0 +1,71 @@
+package toolbox
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+)
+
+var detectMIMETests = []struct {
+	name       string
+	header     []byte
+	filename   string
+	expectMIME string
+	expectExt  string
+}{
+	{name: "png", header: []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A}, filename: "a", expectMIME: "image/png", expectExt: ".png"},
+	{name: "jpeg", header: []byte{0xFF, 0xD8, 0xFF, 0xE0}, filename: "a", expectMIME: "image/jpeg", expectExt: ".jpg"},
+	{name: "gif", header: []byte("GIF89a...."), filename: "a", expectMIME: "image/gif", expectExt: ".gif"},
+	{name: "pdf", header: []byte("%PDF-1.4"), filename: "a", expectMIME: "application/pdf", expectExt: ".pdf"},
+	{name: "zip", header: []byte{0x50, 0x4B, 0x03, 0x04}, filename: "a", expectMIME: "application/zip", expectExt: ".zip"},
+	{name: "webp", header: append([]byte("RIFF\x00\x00\x00\x00WEBP"), 0), filename: "a", expectMIME: "image/webp", expectExt: ".webp"},
+	{name: "mp4", header: []byte("\x00\x00\x00\x18ftypmp42"), filename: "a", expectMIME: "video/mp4", expectExt: ".mp4"},
+	{name: "ogg", header: []byte("OggS\x00\x02"), filename: "a", expectMIME: "audio/ogg", expectExt: ".ogg"},
+	{name: "unknown falls back to filename ext", header: []byte("just some text"), filename: "notes.txt", expectMIME: "text/plain; charset=utf-8", expectExt: ".txt"},
+}
+
+func TestTools_DetectMIME(t *testing.T) {
+
+	var tools Tools
+
+	for _, e := range detectMIMETests {
+		mime, ext := tools.DetectMIME(e.header, e.filename)
+
+		if mime != e.expectMIME {
+			t.Errorf("%s: expected mime %q, got %q", e.name, e.expectMIME, mime)
+		}
+
+		if ext != e.expectExt {
+			t.Errorf("%s: expected ext %q, got %q", e.name, e.expectExt, ext)
+		}
+	}
+}
+
+func TestTools_StrictMIME_RejectsMismatchedExtension(t *testing.T) {
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	part, err := writer.CreateFormFile("file", "fake.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// PNG signature behind a ".pdf" extension.
+	if _, err := part.Write([]byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var tools Tools
+	tools.StrictMIME = true
+
+	if _, err := tools.UploadFiles(req, "./testdata/upload/", false); err == nil {
+		t.Error("expected StrictMIME to reject a PNG disguised as a .pdf")
+	}
+}
@@ -0,0 +1,220 @@
+package toolbox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RemoteOptions configures PushJSONToRemoteCtx.
+type RemoteOptions struct {
+	// Method defaults to POST.
+	Method string
+
+	// Headers are added to the request on every attempt.
+	Headers http.Header
+
+	// Timeout, if set, is applied to the http.Client used for the call.
+	Timeout time.Duration
+
+	// MaxRetries is how many times a failed attempt is retried. Retries
+	// happen on network errors and 5xx/429 responses.
+	MaxRetries int
+
+	// RetryBackoff is the base delay between retries; it doubles each
+	// attempt and gets jitter added. Defaults to 500ms.
+	RetryBackoff time.Duration
+
+	// GzipRequest gzip-encodes the JSON body and sets Content-Encoding.
+	GzipRequest bool
+
+	// ExpectJSON indicates the caller expects a JSON response. It is not
+	// read by PushJSONToRemoteCtx: the response is decoded as JSON
+	// whenever Into is set, whatever ExpectJSON says.
+	ExpectJSON bool
+
+	// Into, if non-nil, receives the decoded JSON response body. The
+	// response Body is closed for the caller in this case.
+	Into interface{}
+
+	// DiscardBody closes the response Body for the caller when Into is
+	// not used and the caller has no need of it.
+	DiscardBody bool
+}
+
+// PushJSONToRemote posts data to the specified url and returns the
+// response, status code, and error if any. It is a thin wrapper over
+// PushJSONToRemoteCtx with no timeout, retries, or body handling beyond
+// what the caller asks for.
+func (t *Tools) PushJSONToRemote(url string, data interface{}, client ...*http.Client) (*http.Response, int, error) {
+
+	var httpClient *http.Client
+	if len(client) > 0 {
+		httpClient = client[0]
+	}
+
+	return t.PushJSONToRemoteCtx(context.Background(), url, data, RemoteOptions{}, httpClient)
+}
+
+// PushJSONToRemoteCtx posts data to url as JSON, retrying on network
+// errors and 5xx/429 responses with exponential backoff and jitter
+// (honouring a Retry-After header when the server sends one). It honours
+// ctx cancellation throughout the call, including while waiting between
+// retries.
+//
+// The caller owns the returned response's Body and must close it, unless
+// opts.Into is set or opts.DiscardBody is true, in which case
+// PushJSONToRemoteCtx closes it before returning.
+func (t *Tools) PushJSONToRemoteCtx(ctx context.Context, url string, data interface{}, opts RemoteOptions, client ...*http.Client) (*http.Response, int, error) {
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	body := jsonData
+	contentEncoding := ""
+
+	if opts.GzipRequest {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+
+		if _, err := gz.Write(jsonData); err != nil {
+			return nil, 0, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, 0, err
+		}
+
+		body = buf.Bytes()
+		contentEncoding = "gzip"
+	}
+
+	httpClient := &http.Client{}
+	if len(client) > 0 && client[0] != nil {
+		httpClient = client[0]
+	}
+	if opts.Timeout > 0 {
+		// Copy rather than mutate, since the caller may be sharing this
+		// *http.Client elsewhere.
+		clientCopy := *httpClient
+		clientCopy.Timeout = opts.Timeout
+		httpClient = &clientCopy
+	}
+
+	method := opts.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	var response *http.Response
+
+	for attempt := 0; ; attempt++ {
+
+		request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, 0, err
+		}
+
+		request.Header.Set("Content-Type", "application/json")
+		if contentEncoding != "" {
+			request.Header.Set("Content-Encoding", contentEncoding)
+		}
+		for key, values := range opts.Headers {
+			for _, v := range values {
+				request.Header.Add(key, v)
+			}
+		}
+
+		response, err = httpClient.Do(request)
+
+		if err == nil && response.StatusCode < 500 && response.StatusCode != http.StatusTooManyRequests {
+			break
+		}
+
+		if attempt >= opts.MaxRetries {
+			if err != nil {
+				return nil, 0, err
+			}
+			break
+		}
+
+		wait := backoffWithJitter(backoff, attempt)
+		if err == nil {
+			if retryAfter := parseRetryAfter(response.Header.Get("Retry-After")); retryAfter > 0 {
+				wait = retryAfter
+			}
+			response.Body.Close()
+		}
+
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	status := response.StatusCode
+
+	if opts.Into != nil {
+		defer response.Body.Close()
+		if err := json.NewDecoder(response.Body).Decode(opts.Into); err != nil {
+			return response, status, err
+		}
+		return response, status, nil
+	}
+
+	if opts.DiscardBody {
+		defer response.Body.Close()
+	}
+
+	return response, status, nil
+}
+
+// parseRetryAfter interprets a Retry-After header as either a number of
+// seconds or an HTTP-date, returning 0 if it is absent or unparsable.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if when, err := http.ParseTime(header); err == nil {
+		return time.Until(when)
+	}
+
+	return 0
+}
+
+// backoffWithJitter returns an exponentially increasing delay for attempt,
+// with up to 50% random jitter added to avoid thundering-herd retries.
+func backoffWithJitter(base time.Duration, attempt int) time.Duration {
+	wait := base * time.Duration(int64(1)<<uint(attempt))
+	jitter := time.Duration(rand.Int63n(int64(wait)/2 + 1))
+	return wait + jitter
+}
+
+// sleepCtx waits for d, returning early with ctx.Err() if ctx is done
+// first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
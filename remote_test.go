@@ -0,0 +1,157 @@
+package toolbox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTools_PushJSONToRemoteCtx_RetriesOnServerError(t *testing.T) {
+
+	attempts := 0
+
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		attempts++
+		if attempts == 1 {
+			return &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Body:       ioutil.NopCloser(nil),
+				Header:     make(http.Header),
+			}
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(nil),
+			Header:     make(http.Header),
+		}
+	})
+
+	var tools Tools
+
+	_, status, err := tools.PushJSONToRemoteCtx(context.Background(), "/", map[string]string{"a": "b"}, RemoteOptions{
+		MaxRetries:   1,
+		RetryBackoff: time.Millisecond,
+	}, client)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("expected final status 200, got %d", status)
+	}
+}
+
+func TestTools_PushJSONToRemoteCtx_Gzip(t *testing.T) {
+
+	var gotEncoding string
+	var gotBody []byte
+
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		gotEncoding = req.Header.Get("Content-Encoding")
+		gotBody, _ = ioutil.ReadAll(req.Body)
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(nil),
+			Header:     make(http.Header),
+		}
+	})
+
+	var tools Tools
+
+	_, _, err := tools.PushJSONToRemoteCtx(context.Background(), "/", map[string]string{"a": "b"}, RemoteOptions{
+		GzipRequest: true,
+	}, client)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotEncoding != "gzip" {
+		t.Errorf("expected Content-Encoding: gzip, got %q", gotEncoding)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(gotBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(decoded, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if payload["a"] != "b" {
+		t.Errorf("expected decompressed body to round-trip, got %v", payload)
+	}
+}
+
+func TestTools_PushJSONToRemoteCtx_Into(t *testing.T) {
+
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"ok":true}`))),
+			Header:     make(http.Header),
+		}
+	})
+
+	var tools Tools
+
+	var into struct {
+		OK bool `json:"ok"`
+	}
+
+	_, _, err := tools.PushJSONToRemoteCtx(context.Background(), "/", map[string]string{}, RemoteOptions{
+		Into: &into,
+	}, client)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !into.OK {
+		t.Error("expected Into to be populated from the response body")
+	}
+}
+
+func TestTools_PushJSONToRemoteCtx_DoesNotMutateCallersClient(t *testing.T) {
+
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(nil),
+			Header:     make(http.Header),
+		}
+	})
+
+	var tools Tools
+
+	_, _, err := tools.PushJSONToRemoteCtx(context.Background(), "/", map[string]string{}, RemoteOptions{
+		Timeout: 5 * time.Second,
+	}, client)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.Timeout != 0 {
+		t.Errorf("expected the caller's client to be left untouched, got Timeout=%s", client.Timeout)
+	}
+}
@@ -0,0 +1,43 @@
+package toolbox
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// StorageBackend is implemented by anything that can receive the bytes of
+// an uploaded file. It lets Tools.UploadFiles stream a part straight to its
+// final destination without knowing whether that destination is the local
+// disk, an object store, or memory.
+type StorageBackend interface {
+	// Create returns a writer for name. The caller closes it once the
+	// part has been fully written.
+	Create(name string) (io.WriteCloser, error)
+
+	// Remove discards whatever Create(name) wrote, for callers that
+	// abandon a part partway through (a disallowed type, an oversized
+	// upload, an I/O error). It is not an error to remove a name that
+	// was never created.
+	Remove(name string) error
+}
+
+// LocalFS is a StorageBackend that writes files beneath Root on the local
+// filesystem.
+type LocalFS struct {
+	Root string
+}
+
+// Create implements StorageBackend by creating name under l.Root.
+func (l *LocalFS) Create(name string) (io.WriteCloser, error) {
+	return os.Create(filepath.Join(l.Root, name))
+}
+
+// Remove implements StorageBackend by deleting name under l.Root.
+func (l *LocalFS) Remove(name string) error {
+	err := os.Remove(filepath.Join(l.Root, name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package toolbox
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestTools_UploadFiles_TooLarge(t *testing.T) {
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	part, err := writer.CreateFormFile("file", "big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(bytes.Repeat([]byte("a"), 100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var tools Tools
+	tools.MaxFileSize = 10
+
+	_, err = tools.UploadFiles(req, "./testdata/upload/", false)
+
+	if err == nil {
+		t.Fatal("expected an error for an oversized upload")
+	}
+
+	if _, ok := err.(*ErrFileTooLarge); !ok {
+		t.Errorf("expected *ErrFileTooLarge, got %T: %v", err, err)
+	}
+
+	if _, err := os.Stat("./testdata/upload/big.bin"); !os.IsNotExist(err) {
+		t.Error("expected the rejected upload to be removed from storage, but it is still there")
+	}
+}
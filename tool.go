@@ -1,18 +1,18 @@
 package toolbox
 
 import (
-	"bytes"
 	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
-	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
 const randomStringSource = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_+"
@@ -23,6 +23,18 @@ type Tools struct {
 	AllowedFileTypes  []string
 	MaxJSONSize       int
 	AllowUnkownFields bool
+	Storage           StorageBackend
+	Metadata          MetadataStore
+	StrictMIME        bool
+}
+
+// ErrFileTooLarge is returned when an uploaded part exceeds MaxFileSize.
+type ErrFileTooLarge struct {
+	MaxFileSize int
+}
+
+func (e *ErrFileTooLarge) Error() string {
+	return fmt.Sprintf("the uploaded file is too big, and must not be larger than %d bytes", e.MaxFileSize)
 }
 
 // RandomString returns a string of random characters of length n
@@ -45,6 +57,9 @@ type UploadedFile struct {
 	NewFileName      string
 	OriginalFileName string
 	FileSize         int64
+	MIME             string
+	Expiry           time.Time
+	DeleteKey        string
 }
 
 func (t *Tools) UploadOneFile(r *http.Request, uploadDir string, rename ...bool) (*UploadedFile, error) {
@@ -64,6 +79,12 @@ func (t *Tools) UploadOneFile(r *http.Request, uploadDir string, rename ...bool)
 	return files[0], nil
 }
 
+// UploadFiles streams every file part of a multipart request straight to
+// t.Storage (or a LocalFS rooted at uploadDir if Storage is unset), without
+// buffering the request body in memory or in temp files the way
+// r.ParseMultipartForm does. Each part is enforced against t.MaxFileSize as
+// it is copied, so oversized uploads fail fast instead of after being fully
+// spooled.
 func (t *Tools) UploadFiles(r *http.Request, uploadDir string, rename ...bool) ([]*UploadedFile, error) {
 
 	renameFile := true
@@ -72,101 +93,150 @@ func (t *Tools) UploadFiles(r *http.Request, uploadDir string, rename ...bool) (
 		renameFile = rename[0]
 	}
 
-	var uploadedFiles []*UploadedFile
-
 	if t.MaxFileSize == 0 {
 		t.MaxFileSize = 1024 * 1024 * 1024
 	}
 
-	err := t.CreateDirIfNotExist(uploadDir)
-	if err != nil {
+	if err := t.CreateDirIfNotExist(uploadDir); err != nil {
 		return nil, err
 	}
 
-	err = r.ParseMultipartForm(int64(t.MaxFileSize))
+	backend := t.Storage
+	if backend == nil {
+		backend = &LocalFS{Root: uploadDir}
+	}
 
+	mr, err := r.MultipartReader()
 	if err != nil {
-		return nil, errors.New("the uploaded file is too big")
+		return nil, err
 	}
 
-	for _, fHeaders := range r.MultipartForm.File {
-
-		for _, hdr := range fHeaders {
-			uploadedFiles, err := func(uploadeFiles []*UploadedFile) ([]*UploadedFile, error) {
-
-				var uploadedFile UploadedFile
-				infile, err := hdr.Open()
-
-				if err != nil {
-					return nil, err
-				}
-				defer infile.Close()
-
-				buff := make([]byte, 512)
-				_, err = infile.Read(buff)
-				if err != nil {
-					return nil, err
-				}
-
-				//check to see if file type is permitted
-				allowed := false
-				fileType := http.DetectContentType(buff)
-
-				if len(t.AllowedFileTypes) > 0 {
-					for _, x := range t.AllowedFileTypes {
-						if strings.EqualFold(fileType, x) {
-							allowed = true
-						}
-					}
-				} else {
-					allowed = true
-				}
-
-				if !allowed {
-					return nil, errors.New("the uploaded file type is not permitted")
-				}
-				_, err = infile.Seek(0, 0)
-
-				if err != nil {
-					return nil, err
-				}
-
-				if renameFile {
-					uploadedFile.NewFileName = fmt.Sprintf("%s%s", t.RandomString(25), filepath.Ext(hdr.Filename))
-				} else {
-					uploadedFile.NewFileName = hdr.Filename
-				}
-				uploadedFile.OriginalFileName = hdr.Filename
-
-				var outfile *os.File
-				defer outfile.Close()
-
-				if outfile, err := os.Create(filepath.Join(uploadDir, uploadedFile.NewFileName)); err != nil {
-					return nil, err
-				} else {
-					fileSize, err := io.Copy(outfile, infile)
-
-					if err != nil {
-						return nil, err
-					}
-
-					uploadedFile.FileSize = fileSize
-				}
-				uploadedFiles = append(uploadedFiles, &uploadedFile)
-				return uploadeFiles, nil
-
-			}(uploadedFiles)
-
-			if err != nil {
-				return uploadedFiles, err
-			}
+	var uploadedFiles []*UploadedFile
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
 		}
+		if err != nil {
+			return uploadedFiles, err
+		}
+
+		if part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		uploadedFile, err := t.uploadPart(part, backend, renameFile)
+		part.Close()
 
+		if err != nil {
+			return uploadedFiles, err
+		}
+
+		uploadedFiles = append(uploadedFiles, uploadedFile)
 	}
 
 	return uploadedFiles, nil
 }
 
+// uploadPart sniffs the MIME type of a single multipart part from its first
+// 512 bytes, checks it against t.AllowedFileTypes, then writes that prefix
+// followed by the remainder of the part to backend. Reading the prefix into
+// memory rather than seeking back on the part lets this work against a
+// streaming multipart.Reader, whose parts cannot be rewound.
+func (t *Tools) uploadPart(part *multipart.Part, backend StorageBackend, renameFile bool) (*UploadedFile, error) {
+
+	var uploadedFile UploadedFile
+
+	prefix := make([]byte, 512)
+	n, err := io.ReadFull(part, prefix)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	prefix = prefix[:n]
+
+	fileType, sniffedExt := t.DetectMIME(prefix, part.FileName())
+
+	if !t.isAllowedType(fileType) {
+		return nil, errors.New("the uploaded file type is not permitted")
+	}
+
+	if t.StrictMIME {
+		if expected, ok := expectedMIMEForExt(filepath.Ext(part.FileName())); ok && expected != fileType {
+			return nil, errMIMEMismatch(part.FileName(), fileType)
+		}
+	}
+
+	if renameFile {
+		uploadedFile.NewFileName = fmt.Sprintf("%s%s", t.RandomString(25), sniffedExt)
+	} else {
+		uploadedFile.NewFileName = part.FileName()
+	}
+	uploadedFile.OriginalFileName = part.FileName()
+	uploadedFile.MIME = fileType
+
+	outfile, err := backend.Create(uploadedFile.NewFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	fileSize, err := writeUploadPart(outfile, part, prefix, int64(t.MaxFileSize))
+	closeErr := outfile.Close()
+
+	if err != nil {
+		_ = backend.Remove(uploadedFile.NewFileName)
+		return nil, err
+	}
+	if closeErr != nil {
+		_ = backend.Remove(uploadedFile.NewFileName)
+		return nil, closeErr
+	}
+
+	uploadedFile.FileSize = fileSize
+
+	return &uploadedFile, nil
+}
+
+// writeUploadPart writes prefix followed by the remainder of part to
+// outfile, enforcing maxFileSize as it copies. On any error, including
+// ErrFileTooLarge, the caller is responsible for discarding whatever was
+// already written.
+func writeUploadPart(outfile io.Writer, part *multipart.Part, prefix []byte, maxFileSize int64) (int64, error) {
+
+	if _, err := outfile.Write(prefix); err != nil {
+		return 0, err
+	}
+
+	remaining := maxFileSize - int64(len(prefix))
+	written, err := io.Copy(outfile, io.LimitReader(part, remaining+1))
+	if err != nil {
+		return 0, err
+	}
+
+	if written > remaining {
+		return 0, &ErrFileTooLarge{MaxFileSize: int(maxFileSize)}
+	}
+
+	return int64(len(prefix)) + written, nil
+}
+
+// isAllowedType reports whether fileType passes t.AllowedFileTypes. An
+// empty AllowedFileTypes list permits every type.
+func (t *Tools) isAllowedType(fileType string) bool {
+	if len(t.AllowedFileTypes) == 0 {
+		return true
+	}
+
+	for _, x := range t.AllowedFileTypes {
+		if strings.EqualFold(fileType, x) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // CreateDirIfNotExist creates a directory and all necessary parents
 func (t *Tools) CreateDirIfNotExist(path string) error {
 
@@ -201,17 +271,6 @@ func (t *Tools) Slugify(s string) (string, error) {
 
 }
 
-// DownloadStaticFiles it downloads a file and tries to force the browser to download it instead of displaying it
-func (t *Tools) DownloadStaticFiles(w http.ResponseWriter, r *http.Request, p, file, displayName string) {
-
-	fp := path.Join(p, file)
-
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename\"%s\"", displayName))
-
-	http.ServeFile(w, r, fp)
-
-}
-
 // JSONResponse is the type used to send JSON around
 type JSONResponse struct {
 	Error   bool        `json:"error"`
@@ -316,43 +375,3 @@ func (t *Tools) ErrorJSON(w http.ResponseWriter, err error, status ...int) error
 	return t.WriteJSON(w, statusCode, payload)
 
 }
-
-// PushJSONToRemote post data to the specify url and returns the response, code and error if any
-func (t *Tools) PushJSONToRemote(url string, data interface{}, client ...*http.Client) (*http.Response, int, error) {
-
-	//create JSON
-	jsonData, err := json.Marshal(data)
-
-	if err != nil {
-		return nil, 0, err
-	}
-
-	//http client
-	httpClient := &http.Client{}
-
-	if len(client) > 0 {
-		httpClient = client[0]
-	}
-
-	//build request
-	request, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-
-	if err != nil {
-		return nil, 0, err
-	}
-
-	request.Header.Set("Content-Type", "application/json")
-
-	//call remote url
-	response, err := httpClient.Do(request)
-
-	if err != nil {
-		return nil, 0, err
-	}
-
-	defer response.Body.Close()
-
-	//send response
-	return response, response.StatusCode, nil
-
-}
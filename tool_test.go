@@ -16,6 +16,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 )
 
 type RoundTripFunc func(req *http.Request) *http.Response
@@ -259,7 +260,7 @@ func TestTools_Slugify(t *testing.T) {
 
 }
 
-func TestTool_DownloadStaticFile(t *testing.T) {
+func TestTool_ServeDownload(t *testing.T) {
 
 	rr := httptest.NewRecorder()
 
@@ -267,11 +268,20 @@ func TestTool_DownloadStaticFile(t *testing.T) {
 
 	var testtools Tools
 
-	testtools.DownloadStaticFiles(rr, req, "./testdata", "tanjiro.jpg", "Tanjiro.jpg")
+	testtools.ServeDownload(rr, req, DownloadOptions{
+		Root:        "./testdata",
+		File:        "tanjiro.jpg",
+		DisplayName: "Tanjiro.jpg",
+	})
 
 	res := rr.Result()
 	defer res.Body.Close()
 
+	info, err := os.Stat("./testdata/tanjiro.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	sizeStr := res.Header["Content-Length"][0]
 
 	size, err := strconv.Atoi(sizeStr)
@@ -280,11 +290,11 @@ func TestTool_DownloadStaticFile(t *testing.T) {
 		t.Error(err)
 	}
 
-	if size != 161289 {
+	if int64(size) != info.Size() {
 		t.Error("wrong content length of", size)
 	}
 
-	if res.Header["Content-Disposition"][0] != "attachment; filename\"Tanjiro.jpg\"" {
+	if res.Header["Content-Disposition"][0] != `attachment; filename="Tanjiro.jpg"; filename*=UTF-8''Tanjiro.jpg` {
 		t.Error("wrong content disposition")
 	}
 
@@ -296,6 +306,48 @@ func TestTool_DownloadStaticFile(t *testing.T) {
 
 }
 
+func TestTool_ServeDownload_SignedURL(t *testing.T) {
+
+	var testtools Tools
+
+	secret := []byte("super-secret")
+
+	opts := DownloadOptions{
+		Root:            "./testdata",
+		File:            "tanjiro.jpg",
+		DisplayName:     "Tanjiro.jpg",
+		SignedURLSecret: secret,
+		Expiry:          time.Now().Add(time.Hour),
+	}
+
+	signedURL, err := testtools.SignDownloadURL("/downloads/tanjiro.jpg", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", signedURL, nil)
+	rr := httptest.NewRecorder()
+
+	if err := testtools.ServeDownload(rr, req, opts); err != nil {
+		t.Error("expected valid signature to be accepted:", err)
+	}
+
+	tamperedOpts := opts
+	tamperedOpts.Expiry = time.Now().Add(-time.Hour)
+
+	tamperedURL, err := testtools.SignDownloadURL("/downloads/tanjiro.jpg", tamperedOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ = http.NewRequest("GET", tamperedURL, nil)
+	rr = httptest.NewRecorder()
+
+	if err := testtools.ServeDownload(rr, req, opts); err == nil {
+		t.Error("expected expired signature to be rejected")
+	}
+}
+
 var jsonTests = []struct {
 	name          string
 	json          string
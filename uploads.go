@@ -0,0 +1,204 @@
+package toolbox
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultBlacklist holds the filenames UploadFilesWithOptions rejects when
+// the caller stores a file under its original (non-randomised) name.
+var defaultBlacklist = []string{"favicon.ico", "index.html", "robots.txt", "crossdomain.xml"}
+
+// UploadOptions configures UploadFilesWithOptions.
+type UploadOptions struct {
+	// Expiry, if non-zero, is recorded against every uploaded file and
+	// used by ReapExpired to decide when to delete it.
+	Expiry time.Time
+
+	// RandomBarename gives uploaded files a random name instead of their
+	// client-supplied one, the same as UploadFiles' rename flag.
+	RandomBarename bool
+
+	// DeleteKey, if set, is the key callers must present to
+	// Tools.DeleteUpload to remove the file. A random one is generated
+	// when left blank.
+	DeleteKey string
+
+	// AccessKey, if set, gates access to the uploaded file behind a key
+	// only its hash is persisted; the toolbox does not enforce access
+	// itself, callers check it against FileMetadata.AccessKeyHash.
+	AccessKey string
+
+	// Blacklist overrides defaultBlacklist for the names rejected when
+	// RandomBarename is false.
+	Blacklist []string
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *Tools) metadataStore(uploadDir string) MetadataStore {
+	if t.Metadata != nil {
+		return t.Metadata
+	}
+	return NewJSONMetadataStore(filepath.Join(uploadDir, ".meta"))
+}
+
+// UploadFilesWithOptions streams multipart files the same way UploadFiles
+// does, but additionally persists per-file metadata (expiry, delete key,
+// access key) via t.Metadata, and rejects reserved filenames when the
+// caller is keeping client-supplied names.
+func (t *Tools) UploadFilesWithOptions(r *http.Request, uploadDir string, opts UploadOptions) ([]*UploadedFile, error) {
+
+	if t.MaxFileSize == 0 {
+		t.MaxFileSize = 1024 * 1024 * 1024
+	}
+
+	if err := t.CreateDirIfNotExist(uploadDir); err != nil {
+		return nil, err
+	}
+
+	backend := t.Storage
+	if backend == nil {
+		backend = &LocalFS{Root: uploadDir}
+	}
+
+	store := t.metadataStore(uploadDir)
+
+	blacklist := opts.Blacklist
+	if blacklist == nil {
+		blacklist = defaultBlacklist
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	var uploadedFiles []*UploadedFile
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return uploadedFiles, err
+		}
+
+		if part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		if !opts.RandomBarename {
+			for _, name := range blacklist {
+				if strings.EqualFold(part.FileName(), name) {
+					part.Close()
+					return uploadedFiles, fmt.Errorf("%q is not an allowed filename", part.FileName())
+				}
+			}
+		}
+
+		uploadedFile, err := t.uploadPart(part, backend, opts.RandomBarename)
+		part.Close()
+
+		if err != nil {
+			return uploadedFiles, err
+		}
+
+		uploadedFile.Expiry = opts.Expiry
+
+		deleteKey := opts.DeleteKey
+		if deleteKey == "" {
+			deleteKey = t.RandomString(32)
+		}
+		uploadedFile.DeleteKey = deleteKey
+
+		meta := &FileMetadata{
+			Name:          uploadedFile.NewFileName,
+			OriginalName:  uploadedFile.OriginalFileName,
+			Size:          uploadedFile.FileSize,
+			MIME:          uploadedFile.MIME,
+			UploadedAt:    time.Now(),
+			Expiry:        opts.Expiry,
+			DeleteKeyHash: hashKey(deleteKey),
+		}
+		if opts.AccessKey != "" {
+			meta.AccessKeyHash = hashKey(opts.AccessKey)
+		}
+
+		if err := store.Save(meta); err != nil {
+			return uploadedFiles, err
+		}
+
+		uploadedFiles = append(uploadedFiles, uploadedFile)
+	}
+
+	return uploadedFiles, nil
+}
+
+// DeleteUpload removes the uploaded file called name from uploadDir along
+// with its metadata, provided providedKey matches the delete key it was
+// uploaded with.
+func (t *Tools) DeleteUpload(uploadDir, name, providedKey string) error {
+
+	store := t.metadataStore(uploadDir)
+
+	meta, err := store.Get(name)
+	if err != nil {
+		return errors.New("upload not found")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashKey(providedKey)), []byte(meta.DeleteKeyHash)) != 1 {
+		return errors.New("invalid delete key")
+	}
+
+	if err := os.Remove(filepath.Join(uploadDir, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return store.Delete(name)
+}
+
+// ReapExpired deletes every file in uploadDir whose metadata carries an
+// expiry in the past. It is safe to call repeatedly from a goroutine on a
+// ticker.
+func (t *Tools) ReapExpired(uploadDir string) error {
+
+	store := t.metadataStore(uploadDir)
+
+	all, err := store.All()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, meta := range all {
+		if meta.Expiry.IsZero() || meta.Expiry.After(now) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(uploadDir, meta.Name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err := store.Delete(meta.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package toolbox
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func newUploadRequest(t *testing.T, fileName string, content []byte) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req
+}
+
+func TestTools_UploadFilesWithOptions_DeleteKey(t *testing.T) {
+
+	uploadDir := "./testdata/upload/"
+
+	var tools Tools
+
+	uploaded, err := tools.UploadFilesWithOptions(newUploadRequest(t, "note.txt", []byte("hello")), uploadDir, UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := uploaded[0]
+
+	if file.DeleteKey == "" {
+		t.Fatal("expected a delete key to be generated")
+	}
+
+	if err := tools.DeleteUpload(uploadDir, file.NewFileName, "wrong key"); err == nil {
+		t.Error("expected the wrong delete key to be rejected")
+	}
+
+	if _, err := os.Stat(uploadDir + file.NewFileName); err != nil {
+		t.Error("file should still exist after a rejected delete")
+	}
+
+	if err := tools.DeleteUpload(uploadDir, file.NewFileName, file.DeleteKey); err != nil {
+		t.Errorf("expected the correct delete key to succeed: %s", err)
+	}
+
+	if _, err := os.Stat(uploadDir + file.NewFileName); !os.IsNotExist(err) {
+		t.Error("expected the file to be removed after a successful delete")
+	}
+}
+
+func TestTools_UploadFilesWithOptions_Blacklist(t *testing.T) {
+
+	var tools Tools
+
+	_, err := tools.UploadFilesWithOptions(newUploadRequest(t, "index.html", []byte("hi")), "./testdata/upload/", UploadOptions{})
+
+	if err == nil {
+		t.Fatal("expected a blacklisted filename to be rejected")
+	}
+}
+
+func TestTools_ReapExpired(t *testing.T) {
+
+	uploadDir := "./testdata/upload/"
+
+	var tools Tools
+
+	uploaded, err := tools.UploadFilesWithOptions(newUploadRequest(t, "expiring.txt", []byte("bye")), uploadDir, UploadOptions{
+		Expiry: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tools.ReapExpired(uploadDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(uploadDir + uploaded[0].NewFileName); !os.IsNotExist(err) {
+		t.Error("expected the expired file to be reaped")
+	}
+}